@@ -0,0 +1,7 @@
+package api
+
+// Register the built-in runtime, reporter and metrics plugins by their
+// side effect only import, the same way containerd wires up its builtins.
+import (
+	_ "github.com/ernoaapa/can/pkg/plugin/builtins"
+)
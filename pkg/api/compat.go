@@ -0,0 +1,268 @@
+package api
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+
+	"github.com/ernoaapa/can/pkg/api/mapping"
+	"github.com/ernoaapa/can/pkg/events"
+	"github.com/ernoaapa/can/pkg/progress"
+	"github.com/ernoaapa/can/pkg/runtime"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// CompatServer implements a subset of the Docker Engine API on top of the
+// existing runtime.Client/mapping layer, so that Docker tooling (CLI,
+// compose, client libraries) can talk to eliot without going through the
+// native gRPC API.
+type CompatServer struct {
+	client runtime.Client
+	events *events.Bus
+	listen string
+	router *mux.Router
+}
+
+// NewCompatServer creates new Docker Engine API compatible HTTP server
+func NewCompatServer(listen string, client runtime.Client, bus *events.Bus) *CompatServer {
+	server := &CompatServer{
+		client: client,
+		events: bus,
+		listen: listen,
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/info", server.handleInfo).Methods("GET")
+	router.HandleFunc("/version", server.handleVersion).Methods("GET")
+	router.HandleFunc("/containers/json", server.handleListContainers).Methods("GET")
+	router.HandleFunc("/containers/{id}/start", server.handleStartContainer).Methods("POST")
+	router.HandleFunc("/containers/{id}/attach", server.handleAttachContainer)
+	router.HandleFunc("/containers/{id}/wait", server.handleWaitContainer).Methods("POST")
+	router.HandleFunc("/images/json", server.handleListImages).Methods("GET")
+	router.HandleFunc("/images/create", server.handleCreateImage).Methods("POST")
+	router.HandleFunc("/events", server.handleEvents).Methods("GET")
+	server.router = router
+
+	return server
+}
+
+// Serve starts the server to serve the Docker compatible HTTP API.
+//
+// Docker clients version their requests with a path prefix like
+// "/v1.40/...", so we strip any leading "/vX.YY" before dispatching to the
+// router, the same way dockerd's own router does.
+func (s *CompatServer) Serve() error {
+	lis, err := net.Listen("tcp", s.listen)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to start Docker compat API server to listen [%s]", s.listen)
+	}
+	return http.Serve(lis, stripVersionPrefix(s.router))
+}
+
+func (s *CompatServer) handleInfo(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"ID":              "eliot",
+		"ServerVersion":   "eliot",
+		"OperatingSystem": "eliot",
+		"Driver":          "containerd",
+	})
+}
+
+func (s *CompatServer) handleVersion(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"Version":    "eliot",
+		"ApiVersion": "1.40",
+		"Os":         "linux",
+	})
+}
+
+func (s *CompatServer) handleListContainers(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	pods, err := s.client.GetPods(namespace)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, mapping.MapPodsToDockerContainers(pods))
+}
+
+func (s *CompatServer) handleStartContainer(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	namespace := r.URL.Query().Get("namespace")
+	container, err := s.client.GetContainer(namespace, id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err := s.client.StartContainer(namespace, container.Name, container.Tty); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *CompatServer) handleWaitContainer(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	namespace := r.URL.Query().Get("namespace")
+	code, err := s.client.WaitContainer(namespace, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"StatusCode": code})
+}
+
+// handleAttachContainer hijacks the HTTP connection and relays stdio. A TTY
+// container gets one raw, unframed byte stream, matching what a terminal on
+// the other end expects; a non-TTY container's stdout/stderr are
+// multiplexed over the single connection using Docker's stdcopy framing (an
+// 8 byte header - 1 byte stream type, 3 bytes padding, 4 byte big endian
+// payload length - in front of every frame) so the client can tell them
+// apart.
+func (s *CompatServer) handleAttachContainer(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	namespace := r.URL.Query().Get("namespace")
+
+	container, err := s.client.GetContainer(namespace, id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("Connection doesn't support hijacking"))
+		return
+	}
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, errors.Wrap(err, "Failed to hijack connection"))
+		return
+	}
+	defer conn.Close()
+
+	buf.WriteString("HTTP/1.1 200 OK\r\nContent-Type: application/vnd.docker.raw-stream\r\n\r\n")
+	buf.Flush()
+
+	attachIO := runtime.AttachIO{Stdin: conn, Stdout: conn, Stderr: conn}
+	if !container.Tty {
+		attachIO.Stdout = newStdcopyWriter(conn, stdcopyStdout)
+		attachIO.Stderr = newStdcopyWriter(conn, stdcopyStderr)
+	}
+
+	if err := s.client.Attach(namespace, id, attachIO); err != nil {
+		log.Warnf("Error while attaching to container [%s]: %s", id, err)
+	}
+}
+
+const (
+	stdcopyStdout byte = 1
+	stdcopyStderr byte = 2
+)
+
+// stdcopyWriter prefixes every Write with Docker's stdcopy frame header: 1
+// byte stream type, 3 bytes padding, 4 byte big endian payload length.
+type stdcopyWriter struct {
+	w      io.Writer
+	stream byte
+}
+
+func newStdcopyWriter(w io.Writer, stream byte) *stdcopyWriter {
+	return &stdcopyWriter{w: w, stream: stream}
+}
+
+func (s *stdcopyWriter) Write(p []byte) (int, error) {
+	header := make([]byte, 8)
+	header[0] = s.stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(p)))
+
+	if _, err := s.w.Write(header); err != nil {
+		return 0, errors.Wrap(err, "Failed to write stdcopy frame header")
+	}
+	return s.w.Write(p)
+}
+
+func (s *CompatServer) handleListImages(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	images, err := s.client.GetImages(namespace)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, mapping.MapImagesToDockerImages(images))
+}
+
+func (s *CompatServer) handleCreateImage(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	image := r.URL.Query().Get("fromImage")
+
+	fetch := progress.NewImageFetch(image, image)
+	if err := s.client.PullImage(namespace, image, fetch); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	fetch.AllDone()
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *CompatServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("Streaming not supported"))
+		return
+	}
+
+	namespace := r.URL.Query().Get("namespace")
+	updates, unsubscribe := s.events.Subscribe(events.MatchNamespace(namespace))
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	encoder := json.NewEncoder(w)
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-updates:
+			if err := encoder.Encode(mapping.MapEventToDockerMessage(event)); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Warnf("Error while writing JSON response: %s", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"message": err.Error()}) // nolint: errcheck
+}
+
+// versionPrefix matches dockerd's own API version prefix, e.g. "/v1.40",
+// so it's only stripped when the path actually starts with one - not for
+// any path segment that merely happens to start with "v", like
+// "/volumes/...".
+var versionPrefix = regexp.MustCompile(`^/v[0-9][0-9.]*(/|$)`)
+
+func stripVersionPrefix(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if loc := versionPrefix.FindStringIndex(r.URL.Path); loc != nil {
+			rest := r.URL.Path[loc[1]:]
+			r.URL.Path = "/" + rest
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/ernoaapa/can/pkg/api/mapping"
+	containers "github.com/ernoaapa/can/pkg/api/services/containers/v1"
+	"github.com/ernoaapa/can/pkg/events"
+	"github.com/ernoaapa/can/pkg/runtime"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxProbeLogEntries bounds the rolling probe history kept per container
+const maxProbeLogEntries = 5
+
+// healthState tracks the rolling probe history and current status for one
+// container's healthcheck. probe() (the only writer) and annotateHealth via
+// lookup (a reader running concurrently on every List call) both touch
+// these fields, so they're guarded by mu rather than relying on the
+// registry's own lock, which is released before callers get to read them.
+type healthState struct {
+	mu           sync.Mutex
+	status       runtime.HealthStatus
+	log          []runtime.ProbeResult
+	failedInARow int
+	startedAt    time.Time
+}
+
+// snapshot returns a copy of the current status and probe log, safe to
+// read without holding mu.
+func (s *healthState) snapshot() (runtime.HealthStatus, []runtime.ProbeResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	log := make([]runtime.ProbeResult, len(s.log))
+	copy(log, s.log)
+	return s.status, log
+}
+
+// healthRegistry keeps healthState per "namespace/container" key
+type healthRegistry struct {
+	mu     sync.Mutex
+	states map[string]*healthState
+}
+
+func newHealthRegistry() *healthRegistry {
+	return &healthRegistry{states: map[string]*healthState{}}
+}
+
+// getOrCreate returns the tracked healthState for a container, creating a
+// fresh "starting" one the first time the probe loop sees it. Only the probe loop should call
+// this - read paths like List must use lookup instead, since creating an
+// entry here is what starts the StartPeriod clock.
+func (r *healthRegistry) getOrCreate(namespace, container string) *healthState {
+	key := namespace + "/" + container
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state, ok := r.states[key]
+	if !ok {
+		state = &healthState{status: runtime.HealthStarting, startedAt: time.Now()}
+		r.states[key] = state
+	}
+	return state
+}
+
+// lookup returns the tracked healthState for a container, or false if it
+// isn't tracked (no healthcheck configured, or not yet seen by the probe
+// loop or HealthCheckRun).
+// Unlike getOrCreate, it never mutates the registry, so it's safe to call
+// from a read path like List.
+func (r *healthRegistry) lookup(namespace, container string) (*healthState, bool) {
+	key := namespace + "/" + container
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state, ok := r.states[key]
+	return state, ok
+}
+
+// HealthCheckRun runs a container's configured healthcheck probe
+// immediately, records the result and returns the resulting health state.
+func (s *Server) HealthCheckRun(ctx context.Context, req *containers.HealthCheckRunRequest) (*containers.HealthCheckRunResponse, error) {
+	container, err := s.client.GetContainer(req.Namespace, req.Container)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to find container [%s] to run healthcheck", req.Container)
+	}
+	if container.Healthcheck == nil {
+		return nil, errors.Errorf("Container [%s] doesn't have a healthcheck configured", req.Container)
+	}
+
+	state := s.probe(req.Namespace, req.Container, container.Healthcheck)
+	status, log := state.snapshot()
+	return &containers.HealthCheckRunResponse{
+		Health: mapping.MapHealthToAPIModel(status, log),
+	}, nil
+}
+
+// healthScanInterval is how often startHealthchecks re-lists pods to check
+// which containers are due for a probe. It's a scheduling resolution, not
+// a per-container probe rate - each container's own Healthcheck.Interval
+// is still what dueForProbe enforces - so there's no point polling faster
+// than the shortest Interval anyone is likely to configure.
+const healthScanInterval = 5 * time.Second
+
+// startHealthchecks launches the background goroutine that periodically
+// probes every running container that has a healthcheck configured. It
+// reuses the Exec plumbing to run the probe command inside the container.
+func (s *Server) startHealthchecks() {
+	go func() {
+		for range time.Tick(healthScanInterval) {
+			pods, err := s.client.GetPods("")
+			if err != nil {
+				log.Warnf("Error while listing pods for healthcheck loop: %s", err)
+				continue
+			}
+
+			for _, pod := range pods {
+				for _, container := range pod.Containers {
+					if container.Healthcheck == nil || container.State != runtime.StateRunning {
+						continue
+					}
+					if s.dueForProbe(pod.Metadata.Namespace, container.Name, container.Healthcheck) {
+						s.probe(pod.Metadata.Namespace, container.Name, container.Healthcheck)
+					}
+				}
+			}
+		}
+	}()
+}
+
+func (s *Server) dueForProbe(namespace, container string, check *runtime.Healthcheck) bool {
+	state := s.health.getOrCreate(namespace, container)
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if len(state.log) == 0 {
+		return time.Since(state.startedAt) >= check.StartPeriod
+	}
+	last := state.log[len(state.log)-1]
+	return time.Since(last.Time) >= check.Interval
+}
+
+// probe execs the healthcheck command inside the container, records the
+// result and transitions the container's HealthStatus, emitting a
+// "health_status" event whenever the status changes.
+func (s *Server) probe(namespace, container string, check *runtime.Healthcheck) *healthState {
+	state := s.health.getOrCreate(namespace, container)
+
+	var output bytes.Buffer
+	exitCode := 1
+	process, err := s.client.Exec(
+		namespace, container,
+		runtime.ExecSpec{Cmd: check.Test},
+		runtime.AttachIO{Stdout: &output, Stderr: &output},
+	)
+	if err == nil {
+		if waitErr := process.Wait(); waitErr == nil {
+			if code, running := process.ExitStatus(); !running {
+				exitCode = int(code)
+			}
+		}
+	}
+
+	result := runtime.ProbeResult{ExitCode: exitCode, Output: output.String(), Time: time.Now()}
+
+	state.mu.Lock()
+	state.log = append(state.log, result)
+	if len(state.log) > maxProbeLogEntries {
+		state.log = state.log[len(state.log)-maxProbeLogEntries:]
+	}
+
+	previous := state.status
+	if exitCode == 0 {
+		state.failedInARow = 0
+		state.status = runtime.HealthHealthy
+	} else {
+		state.failedInARow++
+		if state.failedInARow >= check.Retries {
+			state.status = runtime.HealthUnhealthy
+		}
+	}
+	changed := state.status != previous
+	current := state.status
+	state.mu.Unlock()
+
+	if changed {
+		s.events.Publish(events.Event{
+			Type:        events.Type("health_status"),
+			Namespace:   namespace,
+			ContainerID: container,
+			Attributes:  map[string]string{"status": string(current)},
+			Timestamp:   result.Time,
+		})
+	}
+
+	return state
+}
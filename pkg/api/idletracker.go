@@ -0,0 +1,69 @@
+package api
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// idleTracker counts in-flight gRPC streams so Serve can notice when the
+// server has had no active connections for a while and shut itself down,
+// similar to Podman's idletracker. This is what makes on-demand startup
+// under systemd socket activation actually save resources.
+type idleTracker struct {
+	mu       sync.Mutex
+	active   int
+	idleFrom time.Time
+}
+
+func newIdleTracker() *idleTracker {
+	return &idleTracker{idleFrom: time.Now()}
+}
+
+func (t *idleTracker) streamStarted() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.active++
+}
+
+func (t *idleTracker) streamEnded() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.active--
+	if t.active == 0 {
+		t.idleFrom = time.Now()
+	}
+}
+
+// idleSince returns how long the server has had zero active streams. The
+// second return value is false while at least one stream is in-flight.
+func (t *idleTracker) idleSince() (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.active > 0 {
+		return 0, false
+	}
+	return time.Since(t.idleFrom), true
+}
+
+// interceptor wraps every streaming RPC so the tracker's active count stays
+// accurate without the individual RPC handlers knowing about it.
+func (t *idleTracker) interceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		t.streamStarted()
+		defer t.streamEnded()
+		return handler(srv, ss)
+	}
+}
+
+// unaryInterceptor does the same accounting for unary RPCs, which are
+// otherwise invisible to the stream interceptor above.
+func (t *idleTracker) unaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		t.streamStarted()
+		defer t.streamEnded()
+		return handler(ctx, req)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"io"
 	"syscall"
 
+	containers "github.com/ernoaapa/can/pkg/api/services/containers/v1"
 	pods "github.com/ernoaapa/can/pkg/api/services/pods/v1"
 )
 
@@ -16,6 +17,19 @@ type Client interface {
 	DeletePod(pod *pods.Pod) (*pods.Pod, error)
 	Attach(containerID string, attachIO AttachIO, hooks ...AttachHooks) (err error)
 	Signal(containerID string, signal syscall.Signal) (err error)
+	Stats(containerID string, stream bool) (<-chan *containers.ContainerStats, error)
+	Exec(containerID string, spec ExecSpec, attachIO AttachIO) (execID string, err error)
+	ExecInspect(execID string) (*containers.ExecInspectResponse, error)
+}
+
+// ExecSpec describes a one-off command to run inside an already running
+// container, as opposed to Attach which connects to the existing PID 1
+// process.
+type ExecSpec struct {
+	Cmd     []string
+	Env     []string
+	Workdir string
+	Tty     bool
 }
 
 // PodOpts adds more information to the Pod going to be created
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"crypto/tls"
+	"net"
+	"net/url"
+	"sync"
+
+	"github.com/coreos/go-systemd/activation"
+	"github.com/pkg/errors"
+)
+
+// systemdListeners lazily fetches the sockets systemd passed down through
+// LISTEN_FDS/LISTEN_PID, so that repeated "fd://" listener specs consume
+// them in order without re-reading the environment every time.
+var (
+	systemdOnce      sync.Once
+	systemdListeners []net.Listener
+	systemdErr       error
+)
+
+func nextSystemdListener() (net.Listener, error) {
+	systemdOnce.Do(func() {
+		systemdListeners, systemdErr = activation.Listeners(true)
+	})
+	if systemdErr != nil {
+		return nil, errors.Wrap(systemdErr, "Failed to inherit listeners from systemd")
+	}
+	if len(systemdListeners) == 0 {
+		return nil, errors.New("No listeners were passed down by systemd (LISTEN_FDS unset?)")
+	}
+
+	lis := systemdListeners[0]
+	systemdListeners = systemdListeners[1:]
+	return lis, nil
+}
+
+// listen builds a net.Listener for one listener spec, e.g.
+// "unix:///run/eliot.sock", "tcp://0.0.0.0:5000" or "fd://" for a systemd
+// socket activation managed listener. When tlsConfig is non-nil, tcp and
+// systemd listeners are wrapped so they speak TLS.
+func listen(spec string, tlsConfig *tls.Config) (net.Listener, error) {
+	u, err := url.Parse(spec)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid listener spec [%s]", spec)
+	}
+
+	var lis net.Listener
+	switch u.Scheme {
+	case "unix":
+		lis, err = net.Listen("unix", u.Path)
+	case "tcp", "":
+		lis, err = net.Listen("tcp", u.Host)
+	case "fd":
+		lis, err = nextSystemdListener()
+	default:
+		return nil, errors.Errorf("Unsupported listener scheme [%s] in spec [%s]", u.Scheme, spec)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to listen on [%s]", spec)
+	}
+
+	if tlsConfig != nil && u.Scheme != "unix" {
+		lis = tls.NewListener(lis, tlsConfig)
+	}
+	return lis, nil
+}
@@ -0,0 +1,200 @@
+// Package mapping translates between the runtime's internal domain types
+// (pkg/runtime) and the wire formats the API server speaks: the native
+// gRPC messages (pkg/api/services/.../v1) and the Docker Engine API
+// compatible JSON served by CompatServer.
+package mapping
+
+import (
+	"time"
+
+	containers "github.com/ernoaapa/can/pkg/api/services/containers/v1"
+	eventsapi "github.com/ernoaapa/can/pkg/api/services/events/v1"
+	pods "github.com/ernoaapa/can/pkg/api/services/pods/v1"
+	"github.com/ernoaapa/can/pkg/events"
+	"github.com/ernoaapa/can/pkg/progress"
+	"github.com/ernoaapa/can/pkg/runtime"
+)
+
+// MapPodToInternalModel converts a wire Pod into the runtime's own Pod type
+func MapPodToInternalModel(p *pods.Pod) *runtime.Pod {
+	pod := &runtime.Pod{}
+	if p.Metadata != nil {
+		pod.Metadata = runtime.PodMetadata{Namespace: p.Metadata.Namespace, Name: p.Metadata.Name}
+	}
+	if p.Spec != nil {
+		for _, spec := range p.Spec.Containers {
+			pod.Spec.Containers = append(pod.Spec.Containers, mapContainerSpecToInternalModel(spec))
+		}
+	}
+	return pod
+}
+
+func mapContainerSpecToInternalModel(spec *containers.ContainerSpec) runtime.Container {
+	container := runtime.Container{
+		Name:    spec.Name,
+		Image:   spec.Image,
+		Tty:     spec.Tty,
+		Env:     spec.Env,
+		Workdir: spec.Workdir,
+		Cmd:     spec.Cmd,
+	}
+	if spec.Healthcheck != nil {
+		container.Healthcheck = &runtime.Healthcheck{
+			Test:        spec.Healthcheck.Test,
+			Interval:    time.Duration(spec.Healthcheck.IntervalSeconds) * time.Second,
+			StartPeriod: time.Duration(spec.Healthcheck.StartPeriodSeconds) * time.Second,
+			Retries:     int(spec.Healthcheck.Retries),
+		}
+	}
+	return container
+}
+
+// MapImageFetchProgressToAPIModel converts a set of in-progress image
+// pulls into the wire format streamed back from CreatePodRequest
+func MapImageFetchProgressToAPIModel(progresses []*progress.ImageFetch) []*pods.ImagePullStatus {
+	result := make([]*pods.ImagePullStatus, 0, len(progresses))
+	for _, p := range progresses {
+		current, total := p.Progress()
+		result = append(result, &pods.ImagePullStatus{
+			Name:    p.Image,
+			Current: current,
+			Total:   total,
+			Done:    p.Done(),
+		})
+	}
+	return result
+}
+
+// CreatePodAPIModel builds a wire Pod out of a namespace/name and the
+// containers that belong to it, as returned by runtime.Client.GetContainers
+func CreatePodAPIModel(namespace, name string, containerList []runtime.Container) *pods.Pod {
+	return &pods.Pod{
+		Metadata:   &pods.PodMetadata{Namespace: namespace, Name: name},
+		Containers: mapContainersToAPIModel(containerList),
+	}
+}
+
+// MapPodsToAPIModel converts a list of runtime Pods into their wire format
+func MapPodsToAPIModel(runtimePods []*runtime.Pod) []*pods.Pod {
+	result := make([]*pods.Pod, 0, len(runtimePods))
+	for _, pod := range runtimePods {
+		result = append(result, &pods.Pod{
+			Metadata:   &pods.PodMetadata{Namespace: pod.Metadata.Namespace, Name: pod.Metadata.Name},
+			Containers: mapContainersToAPIModel(pod.Containers),
+		})
+	}
+	return result
+}
+
+func mapContainersToAPIModel(containerList []runtime.Container) []*containers.Container {
+	result := make([]*containers.Container, 0, len(containerList))
+	for _, c := range containerList {
+		result = append(result, &containers.Container{
+			Name:  c.Name,
+			Image: c.Image,
+			Tty:   c.Tty,
+		})
+	}
+	return result
+}
+
+// MapHealthToAPIModel converts a container's tracked probe history into the
+// wire Health message
+func MapHealthToAPIModel(status runtime.HealthStatus, log []runtime.ProbeResult) *containers.Health {
+	entries := make([]containers.ProbeLogEntry, 0, len(log))
+	for _, result := range log {
+		entries = append(entries, containers.ProbeLogEntry{
+			ExitCode:      int32(result.ExitCode),
+			Output:        result.Output,
+			TimestampUnix: result.Time.Unix(),
+		})
+	}
+	return &containers.Health{Status: string(status), Log: entries}
+}
+
+// MapStatsToAPIModel converts a runtime Stats sample into the wire
+// ContainerStats message. When previous is non-nil, CPUPercent is
+// calculated as the container's share of the host's CPU time consumed
+// between the two samples. Unlike `docker stats`, it isn't multiplied by
+// the number of online CPUs, so a container saturating one CPU of a 4 CPU
+// host reads 25%.
+func MapStatsToAPIModel(previous, current *runtime.Stats) *containers.ContainerStats {
+	stats := &containers.ContainerStats{
+		MemoryUsageBytes: current.MemoryUsageBytes,
+		MemoryLimitBytes: current.MemoryLimitBytes,
+		BlockRead:        current.BlockRead,
+		BlockWrite:       current.BlockWrite,
+		NetworkRxBytes:   current.NetworkRxBytes,
+		NetworkTxBytes:   current.NetworkTxBytes,
+		TimestampUnix:    current.Timestamp.Unix(),
+	}
+
+	if previous != nil {
+		cpuDelta := float64(current.CPUUsageNanos - previous.CPUUsageNanos)
+		systemDelta := float64(current.SystemUsageNanos - previous.SystemUsageNanos)
+		if systemDelta > 0 && cpuDelta >= 0 {
+			stats.CPUPercent = (cpuDelta / systemDelta) * 100
+		}
+	}
+
+	return stats
+}
+
+// MapEventToAPIModel converts a bus Event into the wire Event message
+func MapEventToAPIModel(event events.Event) *eventsapi.Event {
+	return &eventsapi.Event{
+		Type:          string(event.Type),
+		Namespace:     event.Namespace,
+		PodID:         event.PodID,
+		ContainerID:   event.ContainerID,
+		Attributes:    event.Attributes,
+		TimestampUnix: event.Timestamp.Unix(),
+	}
+}
+
+// MapEventToDockerMessage converts a bus Event into a Docker Engine API
+// compatible event message, as streamed from GET /events
+func MapEventToDockerMessage(event events.Event) map[string]interface{} {
+	return map[string]interface{}{
+		"Type":   "container",
+		"Action": string(event.Type),
+		"Actor": map[string]interface{}{
+			"ID":         event.ContainerID,
+			"Attributes": event.Attributes,
+		},
+		"time": event.Timestamp.Unix(),
+	}
+}
+
+// MapPodsToDockerContainers converts runtime Pods into the Docker Engine
+// API compatible container list, as returned from GET /containers/json
+func MapPodsToDockerContainers(runtimePods []*runtime.Pod) []map[string]interface{} {
+	result := []map[string]interface{}{}
+	for _, pod := range runtimePods {
+		for _, container := range pod.Containers {
+			result = append(result, map[string]interface{}{
+				"Id":     container.Name,
+				"Names":  []string{"/" + container.Name},
+				"Image":  container.Image,
+				"State":  string(container.State),
+				"Labels": map[string]string{"eliot.namespace": pod.Metadata.Namespace, "eliot.pod": pod.Metadata.Name},
+			})
+		}
+	}
+	return result
+}
+
+// MapImagesToDockerImages converts runtime Images into the Docker Engine
+// API compatible image list, as returned from GET /images/json
+func MapImagesToDockerImages(images []runtime.Image) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(images))
+	for _, image := range images {
+		result = append(result, map[string]interface{}{
+			"Id":          image.Name,
+			"RepoTags":    []string{image.Name},
+			"Size":        image.Size,
+			"VirtualSize": image.Size,
+		})
+	}
+	return result
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"github.com/ernoaapa/can/pkg/events"
+	"github.com/ernoaapa/can/pkg/plugin"
+	"github.com/ernoaapa/can/pkg/runtime"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultRuntimePlugin is the RuntimePlugin initRuntimePlugin uses whenever
+// it's registered. Only when it isn't registered is the first registered
+// RuntimePlugin used instead; the config file doesn't select the runtime.
+const defaultRuntimePlugin = "io.eliot.runtime.v1.containerd"
+
+// NewServerFromConfig builds a Server the same way containerd builds its
+// daemon: by walking the plugin registry (populated by builtins.go's blank
+// imports, plus whatever out-of-tree plugins are blank-imported by the
+// binary) and initializing the RuntimePlugin and ReporterPlugin entries
+// configured in the TOML file at configPath. The TOML file configures each
+// plugin's own section; it doesn't choose between runtime backends, and
+// since builtins.go always registers defaultRuntimePlugin, that is the
+// runtime used. The caller still chooses what to listen on by passing listener specs to
+// the returned Server's Serve method.
+func NewServerFromConfig(configPath string) (*Server, error) {
+	config, err := plugin.LoadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// The bus is created before the runtime plugin so the plugin can
+	// publish container lifecycle events onto the very same bus the
+	// Server's Subscribe RPC and reporter plugins read from.
+	bus := events.NewBus()
+
+	client, err := initRuntimePlugin(config, bus)
+	if err != nil {
+		return nil, err
+	}
+
+	apiserver := NewServer(client, bus)
+
+	if err := startReporterPlugins(config, apiserver); err != nil {
+		return nil, err
+	}
+
+	if err := startMetricsPlugins(config, apiserver); err != nil {
+		return nil, err
+	}
+
+	return apiserver, nil
+}
+
+func initRuntimePlugin(config *plugin.Config, bus *events.Bus) (runtime.Client, error) {
+	runtimePlugins := plugin.ByType(plugin.RuntimePlugin)
+	if len(runtimePlugins) == 0 {
+		return nil, errors.New("No runtime plugin registered, forgot to blank import pkg/plugin/builtins?")
+	}
+
+	registration := runtimePlugins[0]
+	for _, candidate := range runtimePlugins {
+		if candidate.URI() == defaultRuntimePlugin {
+			registration = candidate
+		}
+	}
+
+	instance, err := initPlugin(config, registration, &plugin.InitContext{Events: bus})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to init runtime plugin [%s]", registration.URI())
+	}
+
+	client, ok := instance.(runtime.Client)
+	if !ok {
+		return nil, errors.Errorf("Runtime plugin [%s] doesn't implement runtime.Client", registration.URI())
+	}
+	return client, nil
+}
+
+func startReporterPlugins(config *plugin.Config, apiserver *Server) error {
+	for _, registration := range plugin.ByType(plugin.ReporterPlugin) {
+		instance, err := initPlugin(config, registration, &plugin.InitContext{
+			Client: apiserver.client,
+			Events: apiserver.events,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "Failed to init reporter plugin [%s]", registration.URI())
+		}
+
+		reporter, ok := instance.(interface{ Start() })
+		if !ok {
+			return errors.Errorf("Reporter plugin [%s] doesn't implement Start()", registration.URI())
+		}
+		log.Infof("Starting reporter plugin [%s]", registration.URI())
+		go reporter.Start()
+	}
+	return nil
+}
+
+// metricsExporter is implemented by a MetricsPlugin instance that's ready
+// to be served, e.g. the prometheus builtin's *prometheusExporter.
+type metricsExporter interface {
+	Serve(listen string) error
+}
+
+func startMetricsPlugins(config *plugin.Config, apiserver *Server) error {
+	for _, registration := range plugin.ByType(plugin.MetricsPlugin) {
+		instance, err := initPlugin(config, registration, &plugin.InitContext{
+			Client: apiserver.client,
+			Events: apiserver.events,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "Failed to init metrics plugin [%s]", registration.URI())
+		}
+
+		exporter, ok := instance.(metricsExporter)
+		if !ok {
+			return errors.Errorf("Metrics plugin [%s] doesn't implement Serve(listen string) error", registration.URI())
+		}
+		log.Infof("Starting metrics plugin [%s]", registration.URI())
+		go func(uri string) {
+			if err := exporter.Serve(""); err != nil {
+				log.Errorf("Metrics plugin [%s] stopped: %s", uri, err)
+			}
+		}(registration.URI())
+	}
+	return nil
+}
+
+func initPlugin(config *plugin.Config, registration *plugin.Registration, ctx *plugin.InitContext) (interface{}, error) {
+	ctx.Meta = config.Meta()
+	if primitive, ok := config.Plugins[registration.URI()]; ok {
+		ctx.Config = primitive
+	}
+	return registration.Init(ctx)
+}
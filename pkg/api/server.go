@@ -1,8 +1,12 @@
 package api
 
 import (
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
 	"fmt"
 	"net"
+	"sync"
 	"syscall"
 	"time"
 
@@ -10,8 +14,10 @@ import (
 
 	"github.com/ernoaapa/can/pkg/api/mapping"
 	containers "github.com/ernoaapa/can/pkg/api/services/containers/v1"
+	eventsapi "github.com/ernoaapa/can/pkg/api/services/events/v1"
 	pods "github.com/ernoaapa/can/pkg/api/services/pods/v1"
 	"github.com/ernoaapa/can/pkg/api/stream"
+	"github.com/ernoaapa/can/pkg/events"
 	"github.com/ernoaapa/can/pkg/progress"
 	"github.com/ernoaapa/can/pkg/runtime"
 	"github.com/pkg/errors"
@@ -22,9 +28,49 @@ import (
 
 // Server implements the GRPC API for the can-cli
 type Server struct {
-	client runtime.Client
-	grpc   *grpc.Server
-	listen string
+	client  runtime.Client
+	events  *events.Bus
+	grpc    *grpc.Server
+	idle    *idleTracker
+	execsMu sync.Mutex
+	execs   map[string]*execEntry
+	health  *healthRegistry
+
+	// TLSConfig, when set, is applied to every tcp:// and fd:// listener
+	// passed to Serve, enabling mutual auth for remote endpoints.
+	TLSConfig *tls.Config
+
+	// IdleTimeout, when non-zero, makes Serve return once the server has
+	// had no active gRPC stream for this long, so it can be run under
+	// systemd socket activation and exit on its own between requests.
+	IdleTimeout time.Duration
+}
+
+// execStatus is the lifecycle state of one exec, mirroring Docker/Podman's
+// exec inspect states.
+type execStatus string
+
+// Possible execStatus values
+const (
+	execCreated execStatus = "created"
+	execRunning execStatus = "running"
+	execExited  execStatus = "exited"
+)
+
+// execReapDelay is how long a finished exec's entry is kept around after it
+// exits, so a client that's slow to call ExecInspect can still read the
+// exit code, without leaking entries forever.
+const execReapDelay = time.Minute
+
+// execEntry tracks a pending or running Exec, from the initial Exec call
+// that allocates the exec_id up to the ExecAttach that actually starts it.
+type execEntry struct {
+	namespace string
+	container string
+	spec      runtime.ExecSpec
+	process   runtime.ExecProcess
+	status    execStatus
+	exitCode  uint32
 }
 
 // Create is 'pods' service Create implementation
@@ -119,11 +165,31 @@ func (s *Server) List(context context.Context, req *pods.ListPodsRequest) (*pods
 	if err != nil {
 		return nil, err
 	}
+	result := mapping.MapPodsToAPIModel(p)
+	s.annotateHealth(req.Namespace, result)
 	return &pods.ListPodsResponse{
-		Pods: mapping.MapPodsToAPIModel(p),
+		Pods: result,
 	}, nil
 }
 
+// annotateHealth fills in the current Health for every container that
+// already has a healthcheck tracked in the Server's health registry, so
+// callers don't need a separate HealthCheckRun round-trip just to see the
+// last known status. Containers with no healthcheck configured are left
+// untouched rather than reported as "starting".
+func (s *Server) annotateHealth(namespace string, result []*pods.Pod) {
+	for _, pod := range result {
+		for _, container := range pod.Containers {
+			state, ok := s.health.lookup(namespace, container.Name)
+			if !ok {
+				continue
+			}
+			status, log := state.snapshot()
+			container.Health = mapping.MapHealthToAPIModel(status, log)
+		}
+	}
+}
+
 // Attach connects to process in container and streams stdout and stderr outputs to client
 func (s *Server) Attach(server containers.Containers_AttachServer) error {
 	md, ok := metadata.FromIncomingContext(server.Context())
@@ -164,6 +230,189 @@ func (s *Server) Signal(cxt context.Context, req *containers.SignalRequest) (*co
 	return &containers.SignalResponse{}, nil
 }
 
+// Stats samples the CPU, memory, block I/O and network counters the runtime
+// reports for a container and streams them back as ContainerStats messages. When req.Stream is false
+// a single sample is sent and the stream is closed, mirroring Docker's
+// `stats?stream=0` one-shot mode; otherwise it keeps sampling on req.Interval
+// until the client disconnects.
+func (s *Server) Stats(req *containers.StatsRequest, server containers.Containers_StatsServer) error {
+	interval := time.Duration(req.Interval) * time.Second
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	var previous *runtime.Stats
+	for {
+		current, err := s.client.GetContainerStats(req.Namespace, req.ContainerID)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to get stats for container [%s]", req.ContainerID)
+		}
+
+		if err := server.Send(mapping.MapStatsToAPIModel(previous, current)); err != nil {
+			return errors.Wrap(err, "Failed to send container stats")
+		}
+		previous = current
+
+		if !req.Stream {
+			return nil
+		}
+
+		select {
+		case <-server.Context().Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Subscribe is 'events' service Subscribe implementation. It streams events
+// published on the Server's event bus (pod created, container
+// started/stopped/died, image pulled, health status) until the client
+// disconnects, filtered by namespace and type from the request. The
+// containerd runtime doesn't publish out-of-memory events.
+func (s *Server) Subscribe(req *eventsapi.EventsRequest, server eventsapi.Events_SubscribeServer) error {
+	types := make([]events.Type, len(req.Types))
+	for i, t := range req.Types {
+		types[i] = events.Type(t)
+	}
+
+	ch, unsubscribe := s.events.Subscribe(events.MatchNamespace(req.Namespace), events.MatchTypes(types...))
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-server.Context().Done():
+			return nil
+		case event := <-ch:
+			if err := server.Send(mapping.MapEventToAPIModel(event)); err != nil {
+				return errors.Wrap(err, "Failed to send event")
+			}
+		}
+	}
+}
+
+// Events returns the Server's event bus so runtime clients and reporters can
+// publish onto it and external subscribers can be wired up during startup.
+func (s *Server) Events() *events.Bus {
+	return s.events
+}
+
+// Exec allocates an exec_id for a one-off command to be run inside an
+// already running container's namespaces. It doesn't start the process -
+// the client must open an ExecAttach stream with the returned exec_id to
+// actually wire up stdio and start it, matching the Docker/Podman exec
+// create+start split.
+func (s *Server) Exec(ctx context.Context, req *containers.ExecRequest) (*containers.ExecResponse, error) {
+	id, err := newExecID()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to generate exec id")
+	}
+
+	s.execsMu.Lock()
+	s.execs[id] = &execEntry{
+		namespace: req.Namespace,
+		container: req.Container,
+		spec: runtime.ExecSpec{
+			Cmd:     req.Cmd,
+			Env:     req.Env,
+			Workdir: req.Workdir,
+			Tty:     req.Tty,
+		},
+		status: execCreated,
+	}
+	s.execsMu.Unlock()
+
+	return &containers.ExecResponse{ExecID: id}, nil
+}
+
+// ExecAttach starts the exec process identified by the 'exec' metadata
+// value and streams its stdio, reusing the same stream.NewReader/NewWriter
+// wiring as the regular Attach RPC.
+func (s *Server) ExecAttach(server containers.Containers_ExecAttachServer) error {
+	md, ok := metadata.FromIncomingContext(server.Context())
+	if !ok {
+		return fmt.Errorf("Incoming exec attach request don't have metadata. You must provide 'exec' through metadata")
+	}
+	execID := getMetadataValue(md, "exec")
+	if execID == "" {
+		return fmt.Errorf("You must define 'exec' metadata")
+	}
+
+	s.execsMu.Lock()
+	entry, ok := s.execs[execID]
+	s.execsMu.Unlock()
+	if !ok {
+		return fmt.Errorf("No such exec [%s]", execID)
+	}
+
+	process, err := s.client.Exec(
+		entry.namespace, entry.container, entry.spec,
+		runtime.AttachIO{
+			Stdin:  stream.NewReader(server),
+			Stdout: stream.NewWriter(server, false),
+			Stderr: stream.NewWriter(server, true),
+		},
+	)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to exec in container [%s]", entry.container)
+	}
+
+	s.execsMu.Lock()
+	entry.process = process
+	entry.status = execRunning
+	s.execsMu.Unlock()
+
+	waitErr := process.Wait()
+
+	code, _ := process.ExitStatus()
+	s.execsMu.Lock()
+	entry.status = execExited
+	entry.exitCode = code
+	s.execsMu.Unlock()
+	s.reapExecAfter(execID, execReapDelay)
+
+	return waitErr
+}
+
+// ExecInspect returns the current state of an exec, including its exit code
+// once the process has terminated.
+func (s *Server) ExecInspect(ctx context.Context, req *containers.ExecInspectRequest) (*containers.ExecInspectResponse, error) {
+	s.execsMu.Lock()
+	defer s.execsMu.Unlock()
+
+	entry, ok := s.execs[req.ExecID]
+	if !ok {
+		return nil, fmt.Errorf("No such exec [%s]", req.ExecID)
+	}
+
+	return &containers.ExecInspectResponse{
+		Status:   string(entry.status),
+		Running:  entry.status == execRunning,
+		ExitCode: entry.exitCode,
+	}, nil
+}
+
+// reapExecAfter deletes an exec's entry once it has been exited for delay,
+// so a client that's slow to call ExecInspect can still read the exit code
+// without the execs map growing without bound.
+func (s *Server) reapExecAfter(execID string, delay time.Duration) {
+	time.AfterFunc(delay, func() {
+		s.execsMu.Lock()
+		defer s.execsMu.Unlock()
+		if entry, ok := s.execs[execID]; ok && entry.status == execExited {
+			delete(s.execs, execID)
+		}
+	})
+}
+
+func newExecID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
 func getMetadataValue(md metadata.MD, key string) string {
 	if val, ok := md[key]; ok {
 		return val[0]
@@ -171,25 +420,81 @@ func getMetadataValue(md metadata.MD, key string) string {
 	return ""
 }
 
-// NewServer creates new API server
-func NewServer(listen string, client runtime.Client) *Server {
+// NewServer creates a new API server, publishing and serving events on bus.
+func NewServer(client runtime.Client, bus *events.Bus) *Server {
 	apiserver := &Server{
 		client: client,
-		listen: listen,
+		events: bus,
+		execs:  map[string]*execEntry{},
+		health: newHealthRegistry(),
+		idle:   newIdleTracker(),
 	}
 
-	apiserver.grpc = grpc.NewServer()
+	apiserver.grpc = grpc.NewServer(
+		grpc.StreamInterceptor(apiserver.idle.interceptor()),
+		grpc.UnaryInterceptor(apiserver.idle.unaryInterceptor()),
+	)
 	pods.RegisterPodsServer(apiserver.grpc, apiserver)
 	containers.RegisterContainersServer(apiserver.grpc, apiserver)
+	eventsapi.RegisterEventsServer(apiserver.grpc, apiserver)
+	apiserver.startHealthchecks()
 
 	return apiserver
 }
 
-// Serve starts the server to serve GRPC server
-func (s *Server) Serve() error {
-	lis, err := net.Listen("tcp", s.listen)
-	if err != nil {
-		return errors.Wrapf(err, "Failed to start API server to listen [%s]", s.listen)
+// Serve starts the GRPC server on every given listener spec, e.g.
+// "unix:///run/eliot.sock", "tcp://0.0.0.0:5000" or "fd://" to inherit a
+// systemd socket activation listener. All listeners share the same
+// grpc.Server, so a failure on any one of them stops the others too,
+// instead of leaving their goroutines running forever. It blocks until
+// every listener has stopped, either because of an error or, when
+// IdleTimeout is set, because the server had no active stream for that
+// long.
+func (s *Server) Serve(listeners ...string) error {
+	if len(listeners) == 0 {
+		return errors.New("Serve needs at least one listener spec")
+	}
+
+	errs := make(chan error, len(listeners))
+	for _, spec := range listeners {
+		lis, err := listen(spec, s.TLSConfig)
+		if err != nil {
+			s.grpc.Stop()
+			return err
+		}
+		go func(spec string, lis net.Listener) {
+			log.Infof("API server listening on [%s]", spec)
+			errs <- errors.Wrapf(s.grpc.Serve(lis), "Listener [%s] stopped", spec)
+		}(spec, lis)
+	}
+
+	if s.IdleTimeout > 0 {
+		go s.stopWhenIdle()
+	}
+
+	// The first listener to fail stops the shared grpc.Server, which in
+	// turn makes every other listener's Serve call return too, so none of
+	// the goroutines started above leak.
+	first := <-errs
+	s.grpc.Stop()
+	for i := 1; i < len(listeners); i++ {
+		<-errs
+	}
+	return first
+}
+
+// stopWhenIdle polls the idle tracker and gracefully stops the gRPC server
+// once it has had no active stream for IdleTimeout, so a process started by
+// systemd socket activation can exit on its own between requests.
+func (s *Server) stopWhenIdle() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if idle, ok := s.idle.idleSince(); ok && idle >= s.IdleTimeout {
+			log.Infof("No active connection for %s, stopping server", idle)
+			s.grpc.GracefulStop()
+			return
+		}
 	}
-	return s.grpc.Serve(lis)
 }
@@ -0,0 +1,305 @@
+// Package containers defines the wire messages and gRPC service for the
+// "containers" API: attaching to a container's stdio, signalling it,
+// streaming its resource stats, running one-off exec commands and
+// checking its health.
+package containers
+
+import (
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	"github.com/ernoaapa/can/pkg/api/stream"
+)
+
+// pbMessage is embedded by every message type so it satisfies
+// github.com/golang/protobuf/proto.Message.
+type pbMessage struct{}
+
+func (pbMessage) Reset()         {}
+func (pbMessage) String() string { return "" }
+func (pbMessage) ProtoMessage()  {}
+
+// Health is the last known healthcheck status of a container
+type Health struct {
+	pbMessage
+	Status string
+	Log    []ProbeLogEntry
+}
+
+// ProbeLogEntry is one past healthcheck probe result
+type ProbeLogEntry struct {
+	pbMessage
+	ExitCode      int32
+	Output        string
+	TimestampUnix int64
+}
+
+// Container is a single container, as returned in a pods.Pod
+type Container struct {
+	pbMessage
+	Name   string
+	Image  string
+	Tty    bool
+	Health *Health
+}
+
+// HealthcheckSpec describes how to probe a container's health, as given in
+// a ContainerSpec
+type HealthcheckSpec struct {
+	pbMessage
+	Test               []string
+	IntervalSeconds    int32
+	StartPeriodSeconds int32
+	Retries            int32
+}
+
+// ContainerSpec is the desired state of a single container within a pod,
+// as given to pods.CreatePodRequest
+type ContainerSpec struct {
+	pbMessage
+	Name        string
+	Image       string
+	Tty         bool
+	Env         []string
+	Workdir     string
+	Cmd         []string
+	Healthcheck *HealthcheckSpec
+}
+
+// SignalRequest asks to send a unix signal to a container's PID 1 process
+type SignalRequest struct {
+	pbMessage
+	Namespace   string
+	ContainerID string
+	Signal      int32
+}
+
+// SignalResponse is the (empty) result of a SignalRequest
+type SignalResponse struct {
+	pbMessage
+}
+
+// StatsRequest asks for a container's resource usage, once or streamed
+type StatsRequest struct {
+	pbMessage
+	Namespace   string
+	ContainerID string
+	Stream      bool
+	Interval    int32
+}
+
+// ContainerStats is a single point-in-time sample of a container's
+// resource usage
+type ContainerStats struct {
+	pbMessage
+	Namespace        string
+	ContainerID      string
+	CPUPercent       float64
+	MemoryUsageBytes uint64
+	MemoryLimitBytes uint64
+	BlockRead        uint64
+	BlockWrite       uint64
+	NetworkRxBytes   uint64
+	NetworkTxBytes   uint64
+	TimestampUnix    int64
+}
+
+// ExecRequest allocates an exec_id for a one-off command
+type ExecRequest struct {
+	pbMessage
+	Namespace string
+	Container string
+	Cmd       []string
+	Env       []string
+	Workdir   string
+	Tty       bool
+}
+
+// ExecResponse carries the allocated exec_id
+type ExecResponse struct {
+	pbMessage
+	ExecID string
+}
+
+// ExecInspectRequest asks for the current state of an exec
+type ExecInspectRequest struct {
+	pbMessage
+	ExecID string
+}
+
+// ExecInspectResponse is the current state of an exec
+type ExecInspectResponse struct {
+	pbMessage
+	Status   string
+	Running  bool
+	ExitCode uint32
+}
+
+// HealthCheckRunRequest asks to run a container's healthcheck immediately
+type HealthCheckRunRequest struct {
+	pbMessage
+	Namespace string
+	Container string
+}
+
+// HealthCheckRunResponse is the result of an immediate healthcheck run
+type HealthCheckRunResponse struct {
+	pbMessage
+	Health *Health
+}
+
+// Containers_AttachServer is the server side of the bidirectional Attach
+// stream: the client sends stdin chunks, the server sends stdout/stderr
+// ones.
+type Containers_AttachServer interface {
+	grpc.ServerStream
+	Send(*stream.Chunk) error
+	Recv() (*stream.Chunk, error)
+}
+
+// Containers_ExecAttachServer is the server side of the bidirectional
+// ExecAttach stream, shaped identically to Containers_AttachServer.
+type Containers_ExecAttachServer interface {
+	grpc.ServerStream
+	Send(*stream.Chunk) error
+	Recv() (*stream.Chunk, error)
+}
+
+// Containers_StatsServer is the server side of the Stats stream
+type Containers_StatsServer interface {
+	grpc.ServerStream
+	Send(*ContainerStats) error
+}
+
+// ContainersServer is the "containers" gRPC service
+type ContainersServer interface {
+	Attach(Containers_AttachServer) error
+	Signal(context.Context, *SignalRequest) (*SignalResponse, error)
+	Stats(*StatsRequest, Containers_StatsServer) error
+	Exec(context.Context, *ExecRequest) (*ExecResponse, error)
+	ExecAttach(Containers_ExecAttachServer) error
+	ExecInspect(context.Context, *ExecInspectRequest) (*ExecInspectResponse, error)
+	HealthCheckRun(context.Context, *HealthCheckRunRequest) (*HealthCheckRunResponse, error)
+}
+
+type containersAttachServer struct {
+	grpc.ServerStream
+}
+
+func (s *containersAttachServer) Send(c *stream.Chunk) error { return s.ServerStream.SendMsg(c) }
+func (s *containersAttachServer) Recv() (*stream.Chunk, error) {
+	c := new(stream.Chunk)
+	if err := s.ServerStream.RecvMsg(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+type containersStatsServer struct {
+	grpc.ServerStream
+}
+
+func (s *containersStatsServer) Send(c *ContainerStats) error { return s.ServerStream.SendMsg(c) }
+
+// RegisterContainersServer registers srv as the implementation of the
+// "containers" service on s.
+func RegisterContainersServer(s *grpc.Server, srv ContainersServer) {
+	s.RegisterService(&_Containers_serviceDesc, srv)
+}
+
+var _Containers_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "containers.Containers",
+	HandlerType: (*ContainersServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Signal",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				req := new(SignalRequest)
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				if interceptor == nil {
+					return srv.(ContainersServer).Signal(ctx, req)
+				}
+				return interceptor(ctx, req, &grpc.UnaryServerInfo{Server: srv, FullMethod: "/containers.Containers/Signal"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+					return srv.(ContainersServer).Signal(ctx, req.(*SignalRequest))
+				})
+			},
+		},
+		{
+			MethodName: "Exec",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				req := new(ExecRequest)
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				if interceptor == nil {
+					return srv.(ContainersServer).Exec(ctx, req)
+				}
+				return interceptor(ctx, req, &grpc.UnaryServerInfo{Server: srv, FullMethod: "/containers.Containers/Exec"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+					return srv.(ContainersServer).Exec(ctx, req.(*ExecRequest))
+				})
+			},
+		},
+		{
+			MethodName: "ExecInspect",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				req := new(ExecInspectRequest)
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				if interceptor == nil {
+					return srv.(ContainersServer).ExecInspect(ctx, req)
+				}
+				return interceptor(ctx, req, &grpc.UnaryServerInfo{Server: srv, FullMethod: "/containers.Containers/ExecInspect"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+					return srv.(ContainersServer).ExecInspect(ctx, req.(*ExecInspectRequest))
+				})
+			},
+		},
+		{
+			MethodName: "HealthCheckRun",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				req := new(HealthCheckRunRequest)
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				if interceptor == nil {
+					return srv.(ContainersServer).HealthCheckRun(ctx, req)
+				}
+				return interceptor(ctx, req, &grpc.UnaryServerInfo{Server: srv, FullMethod: "/containers.Containers/HealthCheckRun"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+					return srv.(ContainersServer).HealthCheckRun(ctx, req.(*HealthCheckRunRequest))
+				})
+			},
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName: "Attach",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				return srv.(ContainersServer).Attach(&containersAttachServer{stream})
+			},
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName: "ExecAttach",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				return srv.(ContainersServer).ExecAttach(&containersAttachServer{stream})
+			},
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName: "Stats",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				req := new(StatsRequest)
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				return srv.(ContainersServer).Stats(req, &containersStatsServer{stream})
+			},
+			ServerStreams: true,
+		},
+	},
+	Metadata: "containers.proto",
+}
@@ -0,0 +1,74 @@
+// Package events defines the wire messages and gRPC service for
+// subscribing to the server's lifecycle event stream.
+package events
+
+import (
+	"google.golang.org/grpc"
+)
+
+type pbMessage struct{}
+
+func (pbMessage) Reset()         {}
+func (pbMessage) String() string { return "" }
+func (pbMessage) ProtoMessage()  {}
+
+// EventsRequest subscribes to every event matching Namespace ("" meaning
+// every namespace) and, if set, one of Types
+type EventsRequest struct {
+	pbMessage
+	Namespace string
+	Types     []string
+}
+
+// Event is a single lifecycle event, as streamed back to a subscriber
+type Event struct {
+	pbMessage
+	Type          string
+	Namespace     string
+	PodID         string
+	ContainerID   string
+	Attributes    map[string]string
+	TimestampUnix int64
+}
+
+// Events_SubscribeServer is the server side of the Subscribe stream
+type Events_SubscribeServer interface {
+	grpc.ServerStream
+	Send(*Event) error
+}
+
+// EventsServer is the "events" gRPC service
+type EventsServer interface {
+	Subscribe(*EventsRequest, Events_SubscribeServer) error
+}
+
+type eventsSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (s *eventsSubscribeServer) Send(e *Event) error { return s.ServerStream.SendMsg(e) }
+
+// RegisterEventsServer registers srv as the implementation of the "events"
+// service on s.
+func RegisterEventsServer(s *grpc.Server, srv EventsServer) {
+	s.RegisterService(&_Events_serviceDesc, srv)
+}
+
+var _Events_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "events.Events",
+	HandlerType: (*EventsServer)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName: "Subscribe",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				req := new(EventsRequest)
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				return srv.(EventsServer).Subscribe(req, &eventsSubscribeServer{stream})
+			},
+			ServerStreams: true,
+		},
+	},
+	Metadata: "events.proto",
+}
@@ -0,0 +1,195 @@
+// Package pods defines the wire messages and gRPC service for the "pods"
+// API: creating, starting, deleting and listing pods.
+package pods
+
+import (
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	containers "github.com/ernoaapa/can/pkg/api/services/containers/v1"
+)
+
+type pbMessage struct{}
+
+func (pbMessage) Reset()         {}
+func (pbMessage) String() string { return "" }
+func (pbMessage) ProtoMessage()  {}
+
+// PodMetadata identifies a Pod
+type PodMetadata struct {
+	pbMessage
+	Namespace string
+	Name      string
+}
+
+// PodSpec is the desired state of a Pod
+type PodSpec struct {
+	pbMessage
+	Containers []*containers.ContainerSpec
+}
+
+// Pod is a group of containers sharing a namespace and lifecycle
+type Pod struct {
+	pbMessage
+	Metadata   *PodMetadata
+	Spec       *PodSpec
+	Containers []*containers.Container
+}
+
+// ImagePullStatus reports the progress of a single image being pulled as
+// part of a CreatePodRequest
+type ImagePullStatus struct {
+	pbMessage
+	Name    string
+	Current int64
+	Total   int64
+	Done    bool
+}
+
+// CreatePodRequest asks to create every container in Pod.Spec, pulling
+// their images first
+type CreatePodRequest struct {
+	pbMessage
+	Pod *Pod
+}
+
+// CreatePodStreamResponse reports image pull progress while a
+// CreatePodRequest is being processed
+type CreatePodStreamResponse struct {
+	pbMessage
+	Images []*ImagePullStatus
+}
+
+// StartPodRequest asks to start every container of a previously created pod
+type StartPodRequest struct {
+	pbMessage
+	Namespace string
+	Name      string
+}
+
+// StartPodResponse is the started Pod
+type StartPodResponse struct {
+	pbMessage
+	Pod *Pod
+}
+
+// DeletePodRequest asks to stop and remove every container of a pod
+type DeletePodRequest struct {
+	pbMessage
+	Namespace string
+	Name      string
+}
+
+// DeletePodResponse is the deleted Pod
+type DeletePodResponse struct {
+	pbMessage
+	Pod *Pod
+}
+
+// ListPodsRequest asks for every pod in a namespace ("" meaning every
+// namespace)
+type ListPodsRequest struct {
+	pbMessage
+	Namespace string
+}
+
+// ListPodsResponse is every matching Pod
+type ListPodsResponse struct {
+	pbMessage
+	Pods []*Pod
+}
+
+// Pods_CreateServer is the server side of the Create stream, reporting
+// image pull progress back to the client until creation finishes
+type Pods_CreateServer interface {
+	grpc.ServerStream
+	Send(*CreatePodStreamResponse) error
+}
+
+// PodsServer is the "pods" gRPC service
+type PodsServer interface {
+	Create(*CreatePodRequest, Pods_CreateServer) error
+	Start(context.Context, *StartPodRequest) (*StartPodResponse, error)
+	Delete(context.Context, *DeletePodRequest) (*DeletePodResponse, error)
+	List(context.Context, *ListPodsRequest) (*ListPodsResponse, error)
+}
+
+type podsCreateServer struct {
+	grpc.ServerStream
+}
+
+func (s *podsCreateServer) Send(resp *CreatePodStreamResponse) error {
+	return s.ServerStream.SendMsg(resp)
+}
+
+// RegisterPodsServer registers srv as the implementation of the "pods"
+// service on s.
+func RegisterPodsServer(s *grpc.Server, srv PodsServer) {
+	s.RegisterService(&_Pods_serviceDesc, srv)
+}
+
+var _Pods_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "pods.Pods",
+	HandlerType: (*PodsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Start",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				req := new(StartPodRequest)
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				if interceptor == nil {
+					return srv.(PodsServer).Start(ctx, req)
+				}
+				return interceptor(ctx, req, &grpc.UnaryServerInfo{Server: srv, FullMethod: "/pods.Pods/Start"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+					return srv.(PodsServer).Start(ctx, req.(*StartPodRequest))
+				})
+			},
+		},
+		{
+			MethodName: "Delete",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				req := new(DeletePodRequest)
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				if interceptor == nil {
+					return srv.(PodsServer).Delete(ctx, req)
+				}
+				return interceptor(ctx, req, &grpc.UnaryServerInfo{Server: srv, FullMethod: "/pods.Pods/Delete"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+					return srv.(PodsServer).Delete(ctx, req.(*DeletePodRequest))
+				})
+			},
+		},
+		{
+			MethodName: "List",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				req := new(ListPodsRequest)
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				if interceptor == nil {
+					return srv.(PodsServer).List(ctx, req)
+				}
+				return interceptor(ctx, req, &grpc.UnaryServerInfo{Server: srv, FullMethod: "/pods.Pods/List"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+					return srv.(PodsServer).List(ctx, req.(*ListPodsRequest))
+				})
+			},
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName: "Create",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				req := new(CreatePodRequest)
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				return srv.(PodsServer).Create(req, &podsCreateServer{stream})
+			},
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pods.proto",
+}
@@ -0,0 +1,73 @@
+// Package stream adapts the bidirectional gRPC streams used by the
+// Attach/ExecAttach RPCs into plain io.Reader/io.Writer, so the rest of the
+// API server can wire them into runtime.AttachIO without knowing anything
+// about gRPC.
+package stream
+
+import "io"
+
+// Chunk is one frame of a stdio stream multiplexed over a single gRPC
+// stream: a client sends Stdin chunks, the server sends Stdout/Stderr ones.
+type Chunk struct {
+	Stdin  []byte
+	Stdout []byte
+	Stderr []byte
+}
+
+// Stream is the subset of the generated Containers_AttachServer /
+// Containers_ExecAttachServer interfaces that reader/writer need.
+type Stream interface {
+	Send(*Chunk) error
+	Recv() (*Chunk, error)
+}
+
+// reader adapts Stream.Recv into io.Reader, surfacing only the Stdin field
+// of each chunk.
+type reader struct {
+	stream Stream
+	buf    []byte
+}
+
+// NewReader returns an io.Reader that reads the Stdin chunks sent over s.
+func NewReader(s Stream) io.Reader {
+	return &reader{stream: s}
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		chunk, err := r.stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+		r.buf = chunk.Stdin
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// writer adapts Stream.Send into io.Writer, sending each Write as a Stdout
+// or Stderr chunk depending on stderr.
+type writer struct {
+	stream Stream
+	stderr bool
+}
+
+// NewWriter returns an io.Writer that sends p as Stdout chunks over s, or
+// as Stderr chunks when stderr is true.
+func NewWriter(s Stream, stderr bool) io.Writer {
+	return &writer{stream: s, stderr: stderr}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	chunk := &Chunk{}
+	if w.stderr {
+		chunk.Stderr = p
+	} else {
+		chunk.Stdout = p
+	}
+	if err := w.stream.Send(chunk); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
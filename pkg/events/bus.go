@@ -0,0 +1,122 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Type identifies the kind of lifecycle event that occurred
+type Type string
+
+// Known event types emitted by the runtime. The containerd runtime doesn't
+// publish ContainerOOM; an OOM killed container is reported as
+// ContainerDied.
+const (
+	PodCreated       Type = "pod.created"
+	ContainerStarted Type = "container.started"
+	ContainerStopped Type = "container.stopped"
+	ContainerDied    Type = "container.died"
+	ImagePulled      Type = "image.pulled"
+	ContainerOOM     Type = "container.oom"
+)
+
+// Event is a single structured lifecycle event
+type Event struct {
+	Type        Type
+	Namespace   string
+	PodID       string
+	ContainerID string
+	Attributes  map[string]string
+	Timestamp   time.Time
+}
+
+// Filter decides whether a subscriber is interested in an Event
+type Filter func(Event) bool
+
+// MatchNamespace only lets events for the given namespace through. An empty
+// namespace matches everything.
+func MatchNamespace(namespace string) Filter {
+	return func(e Event) bool {
+		return namespace == "" || e.Namespace == namespace
+	}
+}
+
+// MatchTypes only lets events of the given types through. No types matches
+// everything.
+func MatchTypes(types ...Type) Filter {
+	return func(e Event) bool {
+		if len(types) == 0 {
+			return true
+		}
+		for _, t := range types {
+			if e.Type == t {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+const subscriberBuffer = 16
+
+// Bus is a simple in-process publish/subscribe fan-out for Events. Slow
+// subscribers don't block publishers: events are dropped for a subscriber
+// whose buffer is full instead of stalling the whole bus.
+type Bus struct {
+	mu          sync.Mutex
+	subscribers map[chan Event][]Filter
+}
+
+// NewBus creates a new, empty event Bus
+func NewBus() *Bus {
+	return &Bus{
+		subscribers: map[chan Event][]Filter{},
+	}
+}
+
+// Subscribe registers a new subscriber that only receives events matching
+// all of the given filters. Call the returned function to unsubscribe and
+// release the channel.
+func (b *Bus) Subscribe(filters ...Filter) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	b.subscribers[ch] = filters
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+	return ch, unsubscribe
+}
+
+// Publish fans the event out to every subscriber whose filters all match
+func (b *Bus) Publish(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch, filters := range b.subscribers {
+		if matchesAll(event, filters) {
+			select {
+			case ch <- event:
+			default:
+				// Subscriber is too slow to keep up, drop the event rather
+				// than blocking the publisher.
+			}
+		}
+	}
+}
+
+func matchesAll(event Event, filters []Filter) bool {
+	for _, filter := range filters {
+		if !filter(event) {
+			return false
+		}
+	}
+	return true
+}
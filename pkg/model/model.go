@@ -0,0 +1,8 @@
+// Package model holds the plain data shared between the runtime and the
+// state reporters, independent of any wire format.
+package model
+
+// DeviceInfo identifies the device a reporter is reporting state for.
+type DeviceInfo struct {
+	Name string
+}
@@ -0,0 +1,51 @@
+package builtins
+
+import (
+	"net/http"
+
+	"github.com/ernoaapa/can/pkg/plugin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func init() {
+	plugin.Register(&plugin.Registration{
+		Type: plugin.MetricsPlugin,
+		ID:   "prometheus",
+		Init: initPrometheusMetrics,
+	})
+}
+
+// prometheusConfig is the [plugins."io.eliot.metrics.v1.prometheus"] section
+type prometheusConfig struct {
+	Address string `toml:"address"`
+}
+
+func initPrometheusMetrics(ctx *plugin.InitContext) (interface{}, error) {
+	config := prometheusConfig{Address: ":9090"}
+	if err := ctx.Meta.PrimitiveDecode(ctx.Config, &config); err != nil {
+		return nil, err
+	}
+
+	registry := prometheus.NewRegistry()
+	return &prometheusExporter{registry: registry, address: config.Address}, nil
+}
+
+// prometheusExporter serves a prometheus.Registry over HTTP, satisfying the
+// api package's metricsExporter interface.
+type prometheusExporter struct {
+	registry *prometheus.Registry
+	address  string
+}
+
+// Serve starts an HTTP server exposing the registry on /metrics. An empty
+// listen falls back to the plugin's own configured address, so the api
+// package doesn't need to know the plugin's defaults.
+func (e *prometheusExporter) Serve(listen string) error {
+	if listen == "" {
+		listen = e.address
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(e.registry, promhttp.HandlerOpts{}))
+	return http.ListenAndServe(listen, mux)
+}
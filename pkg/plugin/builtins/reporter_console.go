@@ -0,0 +1,23 @@
+package builtins
+
+import (
+	"github.com/ernoaapa/can/pkg/model"
+	"github.com/ernoaapa/can/pkg/plugin"
+	"github.com/ernoaapa/can/state"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	plugin.Register(&plugin.Registration{
+		Type: plugin.ReporterPlugin,
+		ID:   "console",
+		Init: initConsoleReporter,
+	})
+}
+
+func initConsoleReporter(ctx *plugin.InitContext) (interface{}, error) {
+	if ctx.Events == nil {
+		return nil, errors.New("console reporter plugin requires the Server's event bus")
+	}
+	return state.NewConsoleStateReporter(model.DeviceInfo{}, ctx.Events), nil
+}
@@ -0,0 +1,27 @@
+package builtins
+
+import (
+	"github.com/ernoaapa/can/pkg/plugin"
+	"github.com/ernoaapa/can/pkg/runtime"
+)
+
+func init() {
+	plugin.Register(&plugin.Registration{
+		Type: plugin.RuntimePlugin,
+		ID:   "containerd",
+		Init: initContainerdRuntime,
+	})
+}
+
+// containerdConfig is the [plugins."io.eliot.runtime.v1.containerd"] section
+type containerdConfig struct {
+	Address string `toml:"address"`
+}
+
+func initContainerdRuntime(ctx *plugin.InitContext) (interface{}, error) {
+	config := containerdConfig{Address: "/run/containerd/containerd.sock"}
+	if err := ctx.Meta.PrimitiveDecode(ctx.Config, &config); err != nil {
+		return nil, err
+	}
+	return runtime.NewContainerdClient(config.Address, ctx.Events)
+}
@@ -0,0 +1,47 @@
+package plugin
+
+import (
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+)
+
+// Config is the top level structure of the plugin configuration file, e.g.:
+//
+//	[plugins."io.eliot.runtime.v1.containerd"]
+//	address = "/run/containerd/containerd.sock"
+type Config struct {
+	Plugins map[string]toml.Primitive `toml:"plugins"`
+
+	meta toml.MetaData
+}
+
+// LoadConfig reads and parses a plugin config file. The returned Config
+// keeps the parsed toml.MetaData around so callers can hand each plugin its
+// own, still undecoded, Plugins[uri] section through InitContext.
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{Plugins: map[string]toml.Primitive{}}
+
+	meta, err := toml.DecodeFile(path, cfg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to load plugin config [%s]", path)
+	}
+	cfg.meta = meta
+
+	return cfg, nil
+}
+
+// Meta returns the parsed document's metadata, needed by plugins that want
+// to decode their own config.Primitive via toml.MetaData.PrimitiveDecode.
+func (c *Config) Meta() *toml.MetaData {
+	return &c.meta
+}
+
+// Decode decodes the raw TOML for the plugin identified by uri (e.g.
+// "io.eliot.runtime.v1.containerd") into out.
+func (c *Config) Decode(uri string, out interface{}) error {
+	primitive, ok := c.Plugins[uri]
+	if !ok {
+		return nil
+	}
+	return c.meta.PrimitiveDecode(primitive, out)
+}
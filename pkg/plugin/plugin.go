@@ -0,0 +1,96 @@
+// Package plugin implements a containerd style plugin registry: packages
+// register themselves in an init() function and the Server walks the
+// registry at startup to build its runtime.Client, state reporters and
+// metrics exporters, instead of hard-coding a single implementation of
+// each.
+package plugin
+
+import (
+	"sync"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/ernoaapa/can/pkg/events"
+	"github.com/ernoaapa/can/pkg/runtime"
+)
+
+// Type groups registrations by the extension point they implement
+type Type string
+
+// Built-in plugin types
+const (
+	RuntimePlugin  Type = "io.eliot.runtime.v1"
+	ReporterPlugin Type = "io.eliot.reporter.v1"
+	MetricsPlugin  Type = "io.eliot.metrics.v1"
+)
+
+// InitContext carries everything a plugin's Init function needs to start
+// itself, including the raw TOML for its own config section so it can
+// decode it lazily with its own config struct.
+type InitContext struct {
+	Root string
+
+	// Meta is the full document's metadata, kept around so a plugin can
+	// call Meta.PrimitiveDecode on its own Config section after the fact.
+	Meta *toml.MetaData
+
+	// Config is the plugin's own, not yet decoded, config section, e.g.
+	// the body of [plugins."io.eliot.runtime.v1.containerd"].
+	Config toml.Primitive
+
+	// Client is the already initialized RuntimePlugin instance, available
+	// to ReporterPlugin/MetricsPlugin so they don't each reach for their
+	// own runtime connection.
+	Client runtime.Client
+
+	// Events is the Server's event bus, available to ReporterPlugin so it
+	// can subscribe instead of polling.
+	Events *events.Bus
+}
+
+// Registration describes one pluggable implementation of a Type, keyed by
+// ID, e.g. Type=RuntimePlugin, ID="containerd".
+type Registration struct {
+	Type Type
+	ID   string
+	Init func(*InitContext) (interface{}, error)
+}
+
+// URI returns the registration's fully qualified plugin ID, e.g.
+// "io.eliot.runtime.v1.containerd", matching the TOML config section name.
+func (r *Registration) URI() string {
+	return string(r.Type) + "." + r.ID
+}
+
+var (
+	mu            sync.Mutex
+	registrations []*Registration
+)
+
+// Register adds a plugin implementation to the registry. Call it from an
+// init() function in a builtin or out-of-tree plugin package.
+func Register(r *Registration) {
+	mu.Lock()
+	defer mu.Unlock()
+	registrations = append(registrations, r)
+}
+
+// Graph returns every registration, in the order it was registered
+func Graph() []*Registration {
+	mu.Lock()
+	defer mu.Unlock()
+	graph := make([]*Registration, len(registrations))
+	copy(graph, registrations)
+	return graph
+}
+
+// ByType returns every registration of the given Type
+func ByType(t Type) []*Registration {
+	var matched []*Registration
+	for _, r := range Graph() {
+		if r.Type == t {
+			matched = append(matched, r)
+		}
+	}
+	return matched
+}
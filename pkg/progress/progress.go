@@ -0,0 +1,51 @@
+// Package progress tracks the download progress of a single image pull, so
+// callers can report it back to a client while the pull is still running.
+package progress
+
+import "sync"
+
+// ImageFetch tracks the progress of pulling one image.
+type ImageFetch struct {
+	Name  string
+	Image string
+
+	mu      sync.Mutex
+	current int64
+	total   int64
+	done    bool
+}
+
+// NewImageFetch creates a new ImageFetch tracker for the given image.
+func NewImageFetch(name, image string) *ImageFetch {
+	return &ImageFetch{Name: name, Image: image}
+}
+
+// Update records the number of bytes fetched so far out of total.
+func (f *ImageFetch) Update(current, total int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.current = current
+	f.total = total
+}
+
+// Progress returns the bytes fetched so far and the total size, as last
+// reported by Update.
+func (f *ImageFetch) Progress() (current, total int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.current, f.total
+}
+
+// AllDone marks the fetch as finished.
+func (f *ImageFetch) AllDone() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.done = true
+}
+
+// Done reports whether AllDone has been called.
+func (f *ImageFetch) Done() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.done
+}
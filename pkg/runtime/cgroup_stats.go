@@ -0,0 +1,205 @@
+package runtime
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// cgroupRoot is where the host's cgroup filesystem is mounted. It's a var
+// rather than a const so tests can point it at a fixture tree.
+var cgroupRoot = "/sys/fs/cgroup"
+
+// readCgroupStats samples CPU and memory usage for the container's cgroup,
+// transparently handling both cgroup v1 (separate per-controller
+// hierarchies) and cgroup v2 (single unified hierarchy). containerPath is
+// the cgroup's path relative to its controller/the unified root, e.g.
+// "/eliot/<container-id>".
+func readCgroupStats(containerPath string) (*Stats, error) {
+	if isCgroupV2() {
+		return readCgroupV2Stats(containerPath)
+	}
+	return readCgroupV1Stats(containerPath)
+}
+
+func isCgroupV2() bool {
+	_, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers"))
+	return err == nil
+}
+
+func readCgroupV1Stats(containerPath string) (*Stats, error) {
+	stats := &Stats{Timestamp: time.Now()}
+
+	cpuUsage, err := readUint64File(filepath.Join(cgroupRoot, "cpuacct", containerPath, "cpuacct.usage"))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read cpuacct.usage")
+	}
+	stats.CPUUsageNanos = cpuUsage
+
+	systemUsage, err := readSystemCPUUsage()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read system cpu usage")
+	}
+	stats.SystemUsageNanos = systemUsage
+
+	memUsage, err := readUint64File(filepath.Join(cgroupRoot, "memory", containerPath, "memory.usage_in_bytes"))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read memory.usage_in_bytes")
+	}
+	stats.MemoryUsageBytes = memUsage
+
+	memLimit, err := readUint64File(filepath.Join(cgroupRoot, "memory", containerPath, "memory.limit_in_bytes"))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read memory.limit_in_bytes")
+	}
+	stats.MemoryLimitBytes = memLimit
+
+	read, write, err := readBlkioBytes(filepath.Join(cgroupRoot, "blkio", containerPath, "blkio.throttle.io_service_bytes"))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read blkio.throttle.io_service_bytes")
+	}
+	stats.BlockRead, stats.BlockWrite = read, write
+
+	return stats, nil
+}
+
+func readCgroupV2Stats(containerPath string) (*Stats, error) {
+	stats := &Stats{Timestamp: time.Now()}
+	dir := filepath.Join(cgroupRoot, containerPath)
+
+	cpuStat, err := readKeyValueFile(filepath.Join(dir, "cpu.stat"))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read cpu.stat")
+	}
+	stats.CPUUsageNanos = cpuStat["usage_usec"] * uint64(time.Microsecond)
+
+	systemUsage, err := readSystemCPUUsage()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read system cpu usage")
+	}
+	stats.SystemUsageNanos = systemUsage
+
+	memUsage, err := readUint64File(filepath.Join(dir, "memory.current"))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read memory.current")
+	}
+	stats.MemoryUsageBytes = memUsage
+
+	if limit, err := readUint64File(filepath.Join(dir, "memory.max")); err == nil {
+		stats.MemoryLimitBytes = limit
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dir, "io.stat")); err == nil {
+		// One "MAJ:MIN rbytes=N wbytes=N ..." line per device
+		for _, field := range strings.Fields(string(data)) {
+			kv := strings.SplitN(field, "=", 2)
+			value, err := strconv.ParseUint(kv[len(kv)-1], 10, 64)
+			if len(kv) != 2 || err != nil {
+				continue
+			}
+			if kv[0] == "rbytes" {
+				stats.BlockRead += value
+			} else if kv[0] == "wbytes" {
+				stats.BlockWrite += value
+			}
+		}
+	}
+
+	return stats, nil
+}
+
+// readSystemCPUUsage returns the host's total CPU time spent since boot, in
+// nanoseconds, read from /proc/stat's aggregate "cpu" line. It's the
+// denominator CPU% calculations are normalized against.
+func readSystemCPUUsage() (uint64, error) {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] != "cpu" {
+			continue
+		}
+		var total uint64
+		for _, field := range fields[1:] {
+			value, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			total += value
+		}
+		// /proc/stat reports jiffies (usually 100/s); convert to nanoseconds.
+		return total * uint64(time.Second) / 100, nil
+	}
+	return 0, errors.New("No \"cpu\" line found in /proc/stat")
+}
+
+func readUint64File(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// readKeyValueFile parses cgroup v2's "key value\n..." stat file format.
+func readKeyValueFile(path string) (map[string]uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	result := map[string]uint64{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		result[fields[0]] = value
+	}
+	return result, scanner.Err()
+}
+
+// readBlkioBytes sums cgroup v1's per-device "blkio.throttle.io_service_bytes"
+// entries into total read and write byte counts.
+func readBlkioBytes(path string) (read, write uint64, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			continue
+		}
+		value, convErr := strconv.ParseUint(fields[2], 10, 64)
+		if convErr != nil {
+			continue
+		}
+		switch fields[1] {
+		case "Read":
+			read += value
+		case "Write":
+			write += value
+		}
+	}
+	return read, write, scanner.Err()
+}
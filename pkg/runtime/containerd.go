@@ -0,0 +1,368 @@
+package runtime
+
+import (
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/cio"
+	"github.com/containerd/containerd/namespaces"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/ernoaapa/can/pkg/events"
+	"github.com/ernoaapa/can/pkg/progress"
+)
+
+// ContainerdClient implements Client on top of containerd's own client
+// library, talking to the daemon over its default GRPC socket.
+type ContainerdClient struct {
+	client *containerd.Client
+
+	// events, when non-nil, receives a lifecycle Event for every pod/
+	// container/image operation this client performs, so reporters and the
+	// Server's Subscribe RPC can react without polling.
+	events *events.Bus
+}
+
+// NewContainerdClient connects to the containerd daemon listening on
+// address, e.g. "/run/containerd/containerd.sock". Lifecycle events are
+// published onto bus as containers and images are created, started,
+// stopped and pulled; pass nil to not publish anything.
+func NewContainerdClient(address string, bus *events.Bus) (*ContainerdClient, error) {
+	client, err := containerd.New(address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to connect to containerd at [%s]", address)
+	}
+	return &ContainerdClient{client: client, events: bus}, nil
+}
+
+// publish fans out event to the configured event bus, if any.
+func (c *ContainerdClient) publish(event events.Event) {
+	if c.events == nil {
+		return
+	}
+	event.Timestamp = time.Now()
+	c.events.Publish(event)
+}
+
+func (c *ContainerdClient) ctx(namespace string) context.Context {
+	return namespaces.WithNamespace(context.Background(), namespace)
+}
+
+// GetPods lists every pod in namespace by grouping containerd's containers
+// by the "io.eliot.pod" label set on them at creation time.
+func (c *ContainerdClient) GetPods(namespace string) ([]*Pod, error) {
+	containers, err := c.client.Containers(c.ctx(namespace))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to list containerd containers")
+	}
+
+	byPod := map[string]*Pod{}
+	for _, cc := range containers {
+		info, err := cc.Info(c.ctx(namespace))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to read info for container [%s]", cc.ID())
+		}
+		podName := info.Labels["io.eliot.pod"]
+		pod, ok := byPod[podName]
+		if !ok {
+			pod = &Pod{Metadata: PodMetadata{Namespace: namespace, Name: podName}}
+			byPod[podName] = pod
+		}
+		container, err := c.toContainer(namespace, cc)
+		if err != nil {
+			return nil, err
+		}
+		pod.Containers = append(pod.Containers, *container)
+	}
+
+	pods := make([]*Pod, 0, len(byPod))
+	for _, pod := range byPod {
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
+
+// GetContainers lists the containers belonging to a single pod.
+func (c *ContainerdClient) GetContainers(namespace, podName string) ([]Container, error) {
+	pods, err := c.GetPods(namespace)
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range pods {
+		if pod.Metadata.Name == podName {
+			return pod.Containers, nil
+		}
+	}
+	return nil, errors.Errorf("No pod [%s] found in namespace [%s]", podName, namespace)
+}
+
+// GetContainer returns a single container by id
+func (c *ContainerdClient) GetContainer(namespace, id string) (*Container, error) {
+	cc, err := c.client.LoadContainer(c.ctx(namespace), id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "No such container [%s]", id)
+	}
+	return c.toContainer(namespace, cc)
+}
+
+func (c *ContainerdClient) toContainer(namespace string, cc containerd.Container) (*Container, error) {
+	info, err := cc.Info(c.ctx(namespace))
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to read info for container [%s]", cc.ID())
+	}
+
+	state := StateCreated
+	if task, err := cc.Task(c.ctx(namespace), nil); err == nil {
+		status, err := task.Status(c.ctx(namespace))
+		if err == nil && status.Status == containerd.Running {
+			state = StateRunning
+		}
+	}
+
+	return &Container{
+		Name:  cc.ID(),
+		Image: info.Image,
+		Tty:   info.Labels["io.eliot.tty"] == "true",
+		State: state,
+	}, nil
+}
+
+// CreateContainer creates a new containerd container for a single spec'd
+// container, labelling it with the owning pod's name so GetPods can group
+// it back together.
+func (c *ContainerdClient) CreateContainer(pod *Pod, container Container) error {
+	image, err := c.client.GetImage(c.ctx(pod.Metadata.Namespace), container.Image)
+	if err != nil {
+		return errors.Wrapf(err, "Image [%s] isn't pulled yet", container.Image)
+	}
+
+	_, err = c.client.NewContainer(
+		c.ctx(pod.Metadata.Namespace),
+		container.Name,
+		containerd.WithImage(image),
+		containerd.WithNewSnapshot(container.Name+"-rootfs", image),
+		containerd.WithContainerLabels(map[string]string{
+			"io.eliot.pod": pod.Metadata.Name,
+			"io.eliot.tty": boolLabel(container.Tty),
+		}),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to create container [%s]", container.Name)
+	}
+	c.publish(events.Event{
+		Type:        events.PodCreated,
+		Namespace:   pod.Metadata.Namespace,
+		PodID:       pod.Metadata.Name,
+		ContainerID: container.Name,
+	})
+	return nil
+}
+
+func boolLabel(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
+
+// StartContainer creates and starts the containerd task backing a
+// previously created container.
+func (c *ContainerdClient) StartContainer(namespace, name string, tty bool) error {
+	cc, err := c.client.LoadContainer(c.ctx(namespace), name)
+	if err != nil {
+		return errors.Wrapf(err, "No such container [%s]", name)
+	}
+
+	task, err := cc.NewTask(c.ctx(namespace), cio.NullIO)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to create task for container [%s]", name)
+	}
+	if err := task.Start(c.ctx(namespace)); err != nil {
+		return errors.Wrapf(err, "Failed to start task for container [%s]", name)
+	}
+	c.publish(events.Event{
+		Type:        events.ContainerStarted,
+		Namespace:   namespace,
+		ContainerID: name,
+	})
+	go c.forwardExitStatus(namespace, name, task)
+	return nil
+}
+
+// forwardExitStatus waits for the task's exit status and publishes a
+// ContainerDied event, so subscribers learn about a container that stops on
+// its own (crash, OOM kill, normal exit) without having to poll.
+func (c *ContainerdClient) forwardExitStatus(namespace, name string, task containerd.Task) {
+	status, err := task.Wait(c.ctx(namespace))
+	if err != nil {
+		return
+	}
+	exitStatus := <-status
+
+	attrs := map[string]string{"exitCode": strconv.Itoa(int(exitStatus.ExitCode()))}
+	c.publish(events.Event{
+		Type:        events.ContainerDied,
+		Namespace:   namespace,
+		ContainerID: name,
+		Attributes:  attrs,
+	})
+}
+
+// StopContainer signals the container's task to terminate and deletes it.
+func (c *ContainerdClient) StopContainer(namespace, name string) error {
+	cc, err := c.client.LoadContainer(c.ctx(namespace), name)
+	if err != nil {
+		return errors.Wrapf(err, "No such container [%s]", name)
+	}
+	task, err := cc.Task(c.ctx(namespace), nil)
+	if err != nil {
+		return nil // Never started, nothing to stop
+	}
+	exited, err := task.Wait(c.ctx(namespace))
+	if err != nil {
+		return errors.Wrapf(err, "Failed to wait for task of container [%s]", name)
+	}
+	if err := task.Kill(c.ctx(namespace), syscall.SIGTERM); err != nil {
+		return errors.Wrapf(err, "Failed to signal task for container [%s]", name)
+	}
+	<-exited
+	_, err = task.Delete(c.ctx(namespace))
+	if err != nil {
+		return err
+	}
+	c.publish(events.Event{
+		Type:        events.ContainerStopped,
+		Namespace:   namespace,
+		ContainerID: name,
+	})
+	return nil
+}
+
+// PullImage pulls image into namespace. fetch isn't updated while the pull
+// runs; callers mark it done themselves once PullImage returns.
+func (c *ContainerdClient) PullImage(namespace, image string, fetch *progress.ImageFetch) error {
+	_, err := c.client.Pull(c.ctx(namespace), image, containerd.WithPullUnpack)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to pull image [%s]", image)
+	}
+	c.publish(events.Event{
+		Type:       events.ImagePulled,
+		Namespace:  namespace,
+		Attributes: map[string]string{"image": image},
+	})
+	return nil
+}
+
+// Attach connects to the container's PID 1 process's stdio.
+func (c *ContainerdClient) Attach(namespace, containerID string, attachIO AttachIO) error {
+	cc, err := c.client.LoadContainer(c.ctx(namespace), containerID)
+	if err != nil {
+		return errors.Wrapf(err, "No such container [%s]", containerID)
+	}
+	task, err := cc.Task(c.ctx(namespace), cio.NewAttach(cio.WithStreams(attachIO.Stdin, attachIO.Stdout, attachIO.Stderr)))
+	if err != nil {
+		return errors.Wrapf(err, "Failed to attach to container [%s]", containerID)
+	}
+	status, err := task.Wait(c.ctx(namespace))
+	if err != nil {
+		return err
+	}
+	<-status
+	return nil
+}
+
+// Signal sends sig to the container's PID 1 process.
+func (c *ContainerdClient) Signal(namespace, containerID string, sig syscall.Signal) error {
+	cc, err := c.client.LoadContainer(c.ctx(namespace), containerID)
+	if err != nil {
+		return errors.Wrapf(err, "No such container [%s]", containerID)
+	}
+	task, err := cc.Task(c.ctx(namespace), nil)
+	if err != nil {
+		return errors.Wrapf(err, "Container [%s] has no running task", containerID)
+	}
+	return task.Kill(c.ctx(namespace), sig)
+}
+
+// GetContainerStats samples the container's cgroup for CPU, memory and
+// block I/O counters. Network counters aren't read and are left zero.
+func (c *ContainerdClient) GetContainerStats(namespace, containerID string) (*Stats, error) {
+	cc, err := c.client.LoadContainer(c.ctx(namespace), containerID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "No such container [%s]", containerID)
+	}
+	task, err := cc.Task(c.ctx(namespace), nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Container [%s] has no running task", containerID)
+	}
+	return readCgroupStats(filepath.Join(namespace, task.ID()))
+}
+
+// Exec runs a one-off command inside the container's namespaces
+func (c *ContainerdClient) Exec(namespace, containerID string, spec ExecSpec, attachIO AttachIO) (ExecProcess, error) {
+	cc, err := c.client.LoadContainer(c.ctx(namespace), containerID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "No such container [%s]", containerID)
+	}
+	task, err := cc.Task(c.ctx(namespace), nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Container [%s] has no running task", containerID)
+	}
+
+	execID, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	process, err := task.Exec(c.ctx(namespace), execID, &containerdExecSpec{Args: spec.Cmd, Env: spec.Env, Cwd: spec.Workdir, Tty: spec.Tty},
+		cio.NewCreator(cio.WithStreams(attachIO.Stdin, attachIO.Stdout, attachIO.Stderr)))
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to exec in container [%s]", containerID)
+	}
+	if err := process.Start(c.ctx(namespace)); err != nil {
+		return nil, errors.Wrapf(err, "Failed to start exec in container [%s]", containerID)
+	}
+
+	return &containerdExecProcess{ctx: c.ctx(namespace), process: process}, nil
+}
+
+// GetImages lists the images pulled into namespace
+func (c *ContainerdClient) GetImages(namespace string) ([]Image, error) {
+	images, err := c.client.ListImages(c.ctx(namespace))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to list images")
+	}
+
+	result := make([]Image, 0, len(images))
+	for _, image := range images {
+		size, err := image.Size(c.ctx(namespace))
+		if err != nil {
+			size = 0
+		}
+		result = append(result, Image{Name: image.Name(), Size: size})
+	}
+	return result, nil
+}
+
+// WaitContainer blocks until the container's task exits and returns its
+// exit code.
+func (c *ContainerdClient) WaitContainer(namespace, id string) (int, error) {
+	cc, err := c.client.LoadContainer(c.ctx(namespace), id)
+	if err != nil {
+		return 0, errors.Wrapf(err, "No such container [%s]", id)
+	}
+	task, err := cc.Task(c.ctx(namespace), nil)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Container [%s] has no running task", id)
+	}
+	status, err := task.Wait(c.ctx(namespace))
+	if err != nil {
+		return 0, err
+	}
+	exitStatus := <-status
+	return int(exitStatus.ExitCode()), exitStatus.Error()
+}
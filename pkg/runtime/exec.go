@@ -0,0 +1,51 @@
+package runtime
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/containerd/containerd"
+	"golang.org/x/net/context"
+)
+
+// containerdExecSpec is the process spec passed to containerd's Task.Exec.
+type containerdExecSpec struct {
+	Args []string
+	Env  []string
+	Cwd  string
+	Tty  bool
+}
+
+// containerdExecProcess adapts a containerd.Process into ExecProcess.
+type containerdExecProcess struct {
+	ctx     context.Context
+	process containerd.Process
+}
+
+func (p *containerdExecProcess) Wait() error {
+	status, err := p.process.Wait(p.ctx)
+	if err != nil {
+		return err
+	}
+	<-status
+	return nil
+}
+
+func (p *containerdExecProcess) ExitStatus() (code uint32, running bool) {
+	status, err := p.process.Status(p.ctx)
+	if err != nil {
+		return 0, false
+	}
+	if status.Status != containerd.Stopped {
+		return 0, true
+	}
+	return status.ExitStatus, false
+}
+
+func newID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
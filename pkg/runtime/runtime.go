@@ -0,0 +1,150 @@
+// Package runtime defines the interface the API server uses to talk to a
+// container runtime (containerd, or anything else that implements Client),
+// along with the plain domain types (Pod, Container, Stats, ...) that
+// interface speaks in. Wire formats (gRPC messages, Docker compat JSON)
+// live under pkg/api and are translated to/from these types by
+// pkg/api/mapping.
+package runtime
+
+import (
+	"io"
+	"syscall"
+	"time"
+
+	"github.com/ernoaapa/can/pkg/progress"
+)
+
+// Client is implemented by every runtime backend (e.g. containerd) that the
+// API server can drive.
+type Client interface {
+	GetPods(namespace string) ([]*Pod, error)
+	GetContainers(namespace, podName string) ([]Container, error)
+	GetContainer(namespace, id string) (*Container, error)
+	CreateContainer(pod *Pod, container Container) error
+	StartContainer(namespace, name string, tty bool) error
+	StopContainer(namespace, name string) error
+	PullImage(namespace, image string, fetch *progress.ImageFetch) error
+	Attach(namespace, containerID string, attachIO AttachIO) error
+	Signal(namespace, containerID string, sig syscall.Signal) error
+	GetContainerStats(namespace, containerID string) (*Stats, error)
+	Exec(namespace, container string, spec ExecSpec, attachIO AttachIO) (ExecProcess, error)
+	GetImages(namespace string) ([]Image, error)
+	WaitContainer(namespace, id string) (int, error)
+}
+
+// PodMetadata identifies a Pod
+type PodMetadata struct {
+	Namespace string
+	Name      string
+}
+
+// PodSpec is the desired state of a Pod, as given to CreateContainer
+type PodSpec struct {
+	Containers []Container
+}
+
+// Pod is a group of containers sharing a namespace and lifecycle
+type Pod struct {
+	Metadata   PodMetadata
+	Spec       PodSpec
+	Containers []Container
+}
+
+// ContainerState is the lifecycle state of a single container
+type ContainerState string
+
+// Possible ContainerState values
+const (
+	StateCreated ContainerState = "created"
+	StateRunning ContainerState = "running"
+	StateStopped ContainerState = "stopped"
+)
+
+// Container is a single container, either as spec'd in a PodSpec or as
+// observed running on the host.
+type Container struct {
+	Name        string
+	Image       string
+	Tty         bool
+	Env         []string
+	Workdir     string
+	Cmd         []string
+	Healthcheck *Healthcheck
+	State       ContainerState
+}
+
+// Healthcheck describes how to probe a container's health, matching
+// Docker/Podman's HEALTHCHECK semantics.
+type Healthcheck struct {
+	Test        []string
+	Interval    time.Duration
+	StartPeriod time.Duration
+	Retries     int
+}
+
+// HealthStatus is the current result of a container's healthcheck probes
+type HealthStatus string
+
+// Possible HealthStatus values, matching Docker/Podman's healthcheck states
+const (
+	HealthStarting  HealthStatus = "starting"
+	HealthHealthy   HealthStatus = "healthy"
+	HealthUnhealthy HealthStatus = "unhealthy"
+)
+
+// ProbeResult is the outcome of a single healthcheck probe execution
+type ProbeResult struct {
+	ExitCode int
+	Output   string
+	Time     time.Time
+}
+
+// Image is a pulled container image
+type Image struct {
+	Name string
+	Size int64
+}
+
+// AttachIO wraps the stdio streams the runtime should wire a container or
+// exec process's stdin/stdout/stderr to.
+type AttachIO struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// ExecSpec describes a one-off command to run inside an already running
+// container, as opposed to Attach which connects to the existing PID 1
+// process.
+type ExecSpec struct {
+	Cmd     []string
+	Env     []string
+	Workdir string
+	Tty     bool
+}
+
+// ExecProcess is a running Exec process
+type ExecProcess interface {
+	Wait() error
+	ExitStatus() (code uint32, running bool)
+}
+
+// Stats is a single point-in-time sample of a container's resource usage
+// counters, as read from its cgroup. CPU and network/block-io fields are
+// cumulative counters, not rates. mapping.MapStatsToAPIModel diffs two
+// samples for CPU% only; the other counters are passed through as-is.
+type Stats struct {
+	Timestamp time.Time
+
+	CPUUsageNanos    uint64
+	SystemUsageNanos uint64
+
+	MemoryUsageBytes uint64
+	MemoryLimitBytes uint64
+
+	BlockRead  uint64
+	BlockWrite uint64
+
+	NetworkRxBytes uint64
+	NetworkTxBytes uint64
+}
@@ -1,50 +1,41 @@
 package state
 
 import (
-	"fmt"
-	"time"
-
-	"github.com/ernoaapa/layeryd/model"
-	"github.com/ernoaapa/layeryd/runtime"
+	"github.com/ernoaapa/can/pkg/events"
+	"github.com/ernoaapa/can/pkg/model"
 	log "github.com/sirupsen/logrus"
 )
 
-// ConsoleStateReporter is Reporter implementation what just prints status to stdout
+// ConsoleStateReporter is a Reporter implementation that logs container
+// lifecycle events to stdout as they're published on the runtime's event
+// bus.
 type ConsoleStateReporter struct {
-	info     model.DeviceInfo
-	client   *runtime.ContainerdClient
-	interval time.Duration
+	info model.DeviceInfo
+	bus  *events.Bus
 }
 
-// NewConsoleStateReporter creates new ConsoleStateReporter
-func NewConsoleStateReporter(info model.DeviceInfo, client *runtime.ContainerdClient, interval time.Duration) *ConsoleStateReporter {
-	return &ConsoleStateReporter{
-		info,
-		client,
-		interval,
-	}
+// NewConsoleStateReporter creates a new ConsoleStateReporter that logs every
+// event published on bus.
+func NewConsoleStateReporter(info model.DeviceInfo, bus *events.Bus) *ConsoleStateReporter {
+	return &ConsoleStateReporter{info, bus}
 }
 
-// Start starts printing status to console with given interval
+// Start subscribes to the event bus and logs every lifecycle event until the
+// subscription is closed.
 func (r *ConsoleStateReporter) Start() {
-	for {
-		states, err := getCurrentState(r.client)
-		if err != nil {
-			log.Errorf("Error while reporting current device state: %s", err)
-		} else {
-			r.report(r.info, states)
-		}
-		time.Sleep(r.interval)
+	updates, unsubscribe := r.bus.Subscribe()
+	defer unsubscribe()
+
+	for event := range updates {
+		r.report(event)
 	}
 }
 
-// Report implements Reporter interface by printing out the state to console
-func (r *ConsoleStateReporter) report(info model.DeviceInfo, states map[string]*model.DeviceState) error {
-
-	for namespace, state := range states {
-		log.WithFields(log.Fields{
-			"nr of pods": fmt.Sprintf("%d containers", len(state.Pods)),
-		}).Infof("%s state update", namespace)
-	}
-	return nil
-}
\ No newline at end of file
+// report logs a single lifecycle event
+func (r *ConsoleStateReporter) report(event events.Event) {
+	log.WithFields(log.Fields{
+		"namespace": event.Namespace,
+		"pod":       event.PodID,
+		"container": event.ContainerID,
+	}).Infof("%s", event.Type)
+}